@@ -17,18 +17,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"sync"
 	"time"
 
-	"github.com/go-kit/log"
-	"github.com/go-kit/log/level"
 	"github.com/prometheus-community/json_exporter/config"
 	"github.com/prometheus-community/json_exporter/exporter"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/prometheus/common/promlog"
-	"github.com/prometheus/common/promlog/flag"
+	"github.com/prometheus/common/promslog"
+	"github.com/prometheus/common/promslog/flag"
 	"github.com/prometheus/common/version"
 	"github.com/prometheus/exporter-toolkit/web"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -41,30 +42,48 @@ var (
 	tlsConfigFile = kingpin.Flag("web.config", "[EXPERIMENTAL] Path to config yaml file that can enable TLS or authentication.").Default("").String()
 )
 
+// fetchCaches holds one FetchCache per module name that configures caching.
+var (
+	fetchCachesMu sync.Mutex
+	fetchCaches   = map[string]*exporter.FetchCache{}
+)
+
+func fetchCacheFor(moduleName string, cacheConfig *config.CacheConfig) *exporter.FetchCache {
+	fetchCachesMu.Lock()
+	defer fetchCachesMu.Unlock()
+
+	c, ok := fetchCaches[moduleName]
+	if !ok {
+		c = exporter.NewFetchCache(cacheConfig.MaxEntries)
+		fetchCaches[moduleName] = c
+	}
+	return c
+}
+
 func Run() {
 
-	promlogConfig := &promlog.Config{}
+	promslogConfig := &promslog.Config{}
 
-	flag.AddFlags(kingpin.CommandLine, promlogConfig)
+	flag.AddFlags(kingpin.CommandLine, promslogConfig)
 	kingpin.Version(version.Print("json_exporter"))
 	kingpin.HelpFlag.Short('h')
 	kingpin.Parse()
-	logger := promlog.New(promlogConfig)
+	logger := promslog.New(promslogConfig)
 
-	level.Info(logger).Log("msg", "Starting json_exporter", "version", version.Info())
-	level.Info(logger).Log("msg", "Build context", "build", version.BuildContext())
+	logger.Info("Starting json_exporter", "version", version.Info())
+	logger.Info("Build context", "build", version.BuildContext())
 
-	level.Info(logger).Log("msg", "Loading config file", "file", *configFile)
+	logger.Info("Loading config file", "file", *configFile)
 	config, err := config.LoadConfig(*configFile)
 	if err != nil {
-		level.Error(logger).Log("msg", "Error loading config", "err", err)
+		logger.Error("Error loading config", "err", err)
 		os.Exit(1)
 	}
 	configJSON, err := json.Marshal(config)
 	if err != nil {
-		level.Error(logger).Log("msg", "Failed to marshal config to JSON", "err", err)
+		logger.Error("Failed to marshal config to JSON", "err", err)
 	}
-	level.Info(logger).Log("msg", "Loaded config file", "config", string(configJSON))
+	logger.Info("Loaded config file", "config", string(configJSON))
 
 	if *configCheck {
 		os.Exit(0)
@@ -74,16 +93,19 @@ func Run() {
 	http.HandleFunc("/probe", func(w http.ResponseWriter, req *http.Request) {
 		probeHandler(w, req, logger, config)
 	})
+	http.HandleFunc("/proxy", func(w http.ResponseWriter, req *http.Request) {
+		proxyHandler(w, req, logger, config)
+	})
 
 	server := &http.Server{Addr: *listenAddress}
 	if err := web.ListenAndServe(server, *tlsConfigFile, logger); err != nil {
-		level.Error(logger).Log("msg", "Failed to start the server", "err", err)
+		logger.Error("Failed to start the server", "err", err)
 		os.Exit(1)
 	}
 }
 
-func probeHandler(w http.ResponseWriter, r *http.Request, logger log.Logger, config config.Config) {
-	var timeoutSeconds time.Duration = getTimeoutSeconds(config, logger)
+func probeHandler(w http.ResponseWriter, r *http.Request, logger *slog.Logger, cfg config.Config) {
+	var timeoutSeconds time.Duration = getTimeoutSeconds(cfg, logger)
 	ctx, cancel := context.WithTimeout(r.Context(), timeoutSeconds)
 	defer cancel()
 	r = r.WithContext(ctx)
@@ -92,56 +114,167 @@ func probeHandler(w http.ResponseWriter, r *http.Request, logger log.Logger, con
 	if module == "" {
 		module = "default"
 	}
-	if _, ok := config.Modules[module]; !ok {
+	moduleConfig, ok := cfg.Modules[module]
+	if !ok {
 		http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
-		level.Debug(logger).Log("msg", "Unknown module", "module", module)
+		logger.Debug("Unknown module", "module", module)
+		return
+	}
+	moduleConfig = applyRequestDefaults(moduleConfig, cfg.Request)
+
+	targets := r.URL.Query()["target"]
+	if len(targets) == 0 {
+		if targetsFrom := r.URL.Query().Get("targets_from"); targetsFrom != "" {
+			discovered, err := exporter.DiscoverTargets(ctx, targetsFrom)
+			if err != nil {
+				http.Error(w, "Failed to discover targets from "+targetsFrom+": "+err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			targets = discovered
+		}
+	}
+	if len(targets) == 0 {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
 		return
 	}
 
 	registry := prometheus.NewPedanticRegistry()
 
-	metrics, err := exporter.CreateMetricsList(config.Modules[module])
+	for _, target := range targets {
+		var targetRegisterer prometheus.Registerer = registry
+		if len(targets) > 1 {
+			targetRegisterer = prometheus.WrapRegistererWith(prometheus.Labels{"target": target}, registry)
+		}
+
+		if err := probeTarget(ctx, targetRegisterer, logger, module, moduleConfig, target, r.URL.Query()); err != nil {
+			logger.Error("Failed to probe target", "target", target, "module", module, "err", err)
+			if len(targets) == 1 {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+	}
+
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+}
+
+// applyRequestDefaults fills in a module's method/headers/body from the
+// exporter-wide request defaults wherever the module doesn't set its own,
+// with the module's own headers taking precedence over same-named defaults.
+func applyRequestDefaults(moduleConfig config.Module, defaults config.Request) config.Module {
+	if moduleConfig.Method == "" {
+		moduleConfig.Method = defaults.Method
+	}
+	if moduleConfig.Body == nil {
+		moduleConfig.Body = defaults.Body
+	}
+	if len(defaults.Headers) > 0 {
+		headers := make(map[string]string, len(defaults.Headers)+len(moduleConfig.Headers))
+		for k, v := range defaults.Headers {
+			headers[k] = v
+		}
+		for k, v := range moduleConfig.Headers {
+			headers[k] = v
+		}
+		moduleConfig.Headers = headers
+	}
+	return moduleConfig
+}
+
+// probeTarget fetches and registers the metrics for a single target against
+// registerer, following the module's type/format/cache configuration.
+func probeTarget(ctx context.Context, registerer prometheus.Registerer, logger *slog.Logger, moduleName string, moduleConfig config.Module, target string, query url.Values) error {
+	if moduleConfig.Type == config.ModuleTypePrometheusAPI {
+		return registerer.Register(exporter.NewPrometheusAPICollector(target, *moduleConfig.PrometheusAPI, ctx, logger))
+	}
+
+	fetcher := exporter.NewJSONFetcher(ctx, logger, moduleConfig, query)
+	fetch := func() ([]byte, string, error) { return fetcher.FetchJSON(target) }
+
+	var data []byte
+	var contentType string
+	var err error
+	if moduleConfig.Cache != nil {
+		key := exporter.CacheKey(moduleName, target, moduleConfig.Cache.Key, query)
+		ttl := moduleConfig.Cache.TTL * time.Second
+		data, contentType, err = fetchCacheFor(moduleName, moduleConfig.Cache).Get(moduleName, key, ttl, fetch)
+	} else {
+		data, contentType, err = fetch()
+	}
 	if err != nil {
-		level.Error(logger).Log("msg", "Failed to create metrics list from config", "err", err)
+		return fmt.Errorf("failed to fetch response: %w", err)
 	}
 
-	jsonMetricCollector := exporter.JSONMetricCollector{JSONMetrics: metrics}
-	jsonMetricCollector.Logger = logger
+	format := moduleConfig.Format
+	if format == config.FormatAuto {
+		format = exporter.DetectFormatFromContentType(contentType)
+		logger.Debug("Sniffed response format", "target", target, "content_type", contentType, "format", format)
+	}
 
-	target := r.URL.Query().Get("target")
-	if target == "" {
-		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
-		return
+	if format == config.FormatPrometheus {
+		return registerer.Register(exporter.NewPrometheusTextCollector(moduleConfig.Prefix, data, logger))
 	}
 
-	fetcher := exporter.NewJSONFetcher(ctx, logger, config.Modules[module], r.URL.Query())
-	data, err := fetcher.FetchJSON(target)
+	data, err = exporter.DecodeToJSON(format, data)
 	if err != nil {
-		http.Error(w, "Failed to fetch JSON response. TARGET: "+target+", ERROR: "+err.Error(), http.StatusServiceUnavailable)
-		return
+		return fmt.Errorf("failed to decode %s response: %w", format, err)
 	}
 
 	if !json.Valid(data) {
-		http.Error(w, "Failed to fetch JSON response. TARGET: "+target+", Fetched invalid response: \n\n"+string(data), http.StatusServiceUnavailable)
+		return fmt.Errorf("fetched invalid response: \n\n%s", string(data))
+	}
+
+	metrics, err := exporter.CreateMetricsList(moduleConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create metrics list from config: %w", err)
+	}
+
+	return registerer.Register(exporter.JSONMetricCollector{JSONMetrics: metrics, Data: data, Logger: logger})
+}
+
+func proxyHandler(w http.ResponseWriter, r *http.Request, logger *slog.Logger, cfg config.Config) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name parameter is missing", http.StatusBadRequest)
 		return
 	}
 
-	jsonMetricCollector.Data = data
+	target, ok := cfg.Proxies[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown proxy target %q", name), http.StatusBadRequest)
+		return
+	}
 
-	registry.MustRegister(jsonMetricCollector)
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
-	h.ServeHTTP(w, r)
+	timeout := target.TimeoutSeconds * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	data, contentType, err := exporter.FetchProxyTarget(ctx, target)
+	if err != nil {
+		http.Error(w, "Failed to proxy to "+name+": "+err.Error(), http.StatusServiceUnavailable)
+		logger.Error("Failed to proxy request", "name", name, "err", err)
+		return
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(data)
 }
 
-func getTimeoutSeconds(config config.Config, logger log.Logger) time.Duration {
-	var timeoutSeconds time.Duration = config.Request.TimeoutSeconds * time.Second
+func getTimeoutSeconds(cfg config.Config, logger *slog.Logger) time.Duration {
+	var timeoutSeconds time.Duration = cfg.Request.TimeoutSeconds * time.Second
 
 	// Default to be 30s
 	if timeoutSeconds.Seconds() < 1.0 {
 		timeoutSeconds = 30 * time.Second
 	}
 
-	level.Debug(logger).Log("timeout", timeoutSeconds)
+	logger.Debug("timeout", "timeoutSeconds", timeoutSeconds)
 
 	return timeoutSeconds
 }
@@ -0,0 +1,274 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	config_util "github.com/prometheus/common/config"
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the top level configuration object.
+type Config struct {
+	Modules map[string]Module      `yaml:",inline"`
+	Request Request                `yaml:"request,omitempty"`
+	Proxies map[string]ProxyTarget `yaml:"proxies,omitempty"`
+}
+
+// ProxyTargetType selects how a ProxyTarget's /proxy request is served.
+type ProxyTargetType string
+
+const (
+	// ProxyTargetExec spawns Command as a child process and returns its
+	// stdout.
+	ProxyTargetExec ProxyTargetType = "exec"
+	// ProxyTargetHTTPForward round-trips the request to URL and returns its
+	// response verbatim.
+	ProxyTargetHTTPForward ProxyTargetType = "http_forward"
+)
+
+// ProxyTarget is a named downstream exporter, reachable through /proxy,
+// letting json_exporter act as a single ingress point for a fleet of small
+// exporters (the "exporter of exporters" pattern).
+type ProxyTarget struct {
+	Type ProxyTargetType `yaml:"type"`
+	// Command and Args are used when Type is ProxyTargetExec.
+	Command string   `yaml:"command,omitempty"`
+	Args    []string `yaml:"args,omitempty"`
+	// URL is used when Type is ProxyTargetHTTPForward.
+	URL string `yaml:"url,omitempty"`
+	// TimeoutSeconds bounds how long the downstream is given to respond.
+	// Defaults to 30s.
+	TimeoutSeconds time.Duration `yaml:"timeout,omitempty"`
+	// MaxOutputBytes bounds how much of the downstream's output is kept.
+	// Zero means unbounded.
+	MaxOutputBytes int64 `yaml:"max_output_bytes,omitempty"`
+}
+
+// Request holds the defaults applied to every probe unless a module
+// overrides them.
+type Request struct {
+	Method         string            `yaml:"method,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"`
+	Body           *Body             `yaml:"body,omitempty"`
+	TimeoutSeconds time.Duration     `yaml:"timeout,omitempty"`
+}
+
+// Body describes a request body, optionally templated with the query
+// parameters of the incoming /probe request.
+type Body struct {
+	Content    string `yaml:"content"`
+	Templatize bool   `yaml:"templatize,omitempty"`
+}
+
+// ModuleType selects how a Module's /probe request is served.
+type ModuleType string
+
+const (
+	// ModuleTypeJSON scrapes a JSON document and extracts metrics from it
+	// via JSONPath, as configured by Metrics. This is the default.
+	ModuleTypeJSON ModuleType = "json"
+	// ModuleTypePrometheusAPI queries a target Prometheus' HTTP API (e.g.
+	// alerts, rules, targets) and re-exposes the results as metrics, as
+	// configured by PrometheusAPI.
+	ModuleTypePrometheusAPI ModuleType = "prometheus_api"
+)
+
+// PrometheusAPIConfig configures a ModuleTypePrometheusAPI module.
+type PrometheusAPIConfig struct {
+	// Endpoints selects which target Prometheus API calls to make: any of
+	// "alerts", "rules", "targets".
+	Endpoints []string `yaml:"endpoints"`
+	// AlertLabels selects which labels to propagate from each alert's label
+	// set onto prometheus_alert, in addition to the fixed "state" label.
+	// Defaults to ["alertname", "severity"].
+	AlertLabels []string `yaml:"alert_labels,omitempty"`
+}
+
+// ResponseFormat identifies how to decode the raw bytes fetched from the
+// target before handing them to the JSONPath-based metric extraction.
+type ResponseFormat string
+
+const (
+	// FormatJSON treats the response as-is. This is the default.
+	FormatJSON ResponseFormat = "json"
+	// FormatPrometheus treats the response as Prometheus text exposition
+	// format and re-emits its counter, gauge and untyped samples (optionally
+	// under Module.Prefix) instead of running JSONPath extraction. Histogram
+	// and summary families are not re-emitted.
+	FormatPrometheus ResponseFormat = "prometheus"
+	// FormatXML converts an XML response to JSON before JSONPath extraction.
+	FormatXML ResponseFormat = "xml"
+	// FormatYAML converts a YAML response to JSON before JSONPath extraction.
+	FormatYAML ResponseFormat = "yaml"
+	// FormatAuto picks one of the above based on the upstream response's
+	// Content-Type header, falling back to FormatJSON when the header is
+	// missing or unrecognized.
+	FormatAuto ResponseFormat = "auto"
+)
+
+// CacheConfig enables coalescing and caching of upstream fetches for a
+// module, so that many concurrent /probe requests for the same logical
+// target reuse one upstream HTTP call.
+type CacheConfig struct {
+	// TTL is how long a fetched response is reused for. A zero TTL disables
+	// caching (but requests still get deduplicated in-flight).
+	TTL time.Duration `yaml:"ttl,omitempty"`
+	// MaxEntries bounds the number of distinct cache keys kept in memory,
+	// evicting the least recently used entry once exceeded. Zero means
+	// unbounded.
+	MaxEntries int `yaml:"max_entries,omitempty"`
+	// Key lists which of "module", "target", or a query parameter name
+	// make up the cache key. Defaults to ["module", "target"].
+	Key []string `yaml:"key,omitempty"`
+}
+
+// Module is a named collection of metrics to extract from a single JSON
+// document, along with how to fetch that document.
+type Module struct {
+	Type             ModuleType                   `yaml:"type,omitempty"`
+	Format           ResponseFormat               `yaml:"format,omitempty"`
+	Prefix           string                       `yaml:"prefix,omitempty"`
+	Method           string                       `yaml:"method,omitempty"`
+	Headers          map[string]string            `yaml:"headers,omitempty"`
+	Body             *Body                        `yaml:"body,omitempty"`
+	HTTPClientConfig config_util.HTTPClientConfig `yaml:"http_client_config,omitempty"`
+	Metrics          []Metric                     `yaml:"metrics"`
+	PrometheusAPI    *PrometheusAPIConfig         `yaml:"prometheus_api,omitempty"`
+	Cache            *CacheConfig                 `yaml:"cache,omitempty"`
+}
+
+// ScrapeType identifies how a Metric's value(s) should be extracted from
+// the scraped JSON document.
+type ScrapeType string
+
+const (
+	// ValueScrape extracts a single scalar value.
+	ValueScrape ScrapeType = "value"
+	// ObjectScrape extracts one metric per element of a JSON array.
+	ObjectScrape ScrapeType = "object"
+	// HistogramScrape extracts a classic or native Prometheus histogram
+	// from either an array of observations or a pre-aggregated
+	// {count, sum, buckets} object.
+	HistogramScrape ScrapeType = "histogram"
+)
+
+// DefaultNativeHistogramSchema is used when a native histogram metric does
+// not set an explicit schema.
+const DefaultNativeHistogramSchema int32 = 3
+
+// HistogramConfig configures a HistogramScrape metric. Exactly one of
+// ValuesPath or AggregatedPath must be set.
+type HistogramConfig struct {
+	// ValuesPath is a JSONPath yielding an array of raw observed values,
+	// each of which is mapped into the appropriate bucket.
+	ValuesPath string `yaml:"values_path,omitempty"`
+	// AggregatedPath is a JSONPath yielding a pre-aggregated
+	// {count, sum, buckets: {"<le>": <count>, ...}} object, as commonly
+	// exposed by application status endpoints.
+	AggregatedPath string `yaml:"aggregated_path,omitempty"`
+	// Native selects a sparse, native Prometheus histogram instead of a
+	// classic bucketed one. Buckets is ignored when Native is true.
+	Native bool `yaml:"native,omitempty"`
+	// Schema is the native histogram resolution schema; buckets grow by a
+	// factor of base = 2^(2^-schema). A nil Schema (including an absent
+	// schema: key) defaults to DefaultNativeHistogramSchema; a pointer is
+	// used because 0 is itself a valid schema.
+	Schema *int32 `yaml:"schema,omitempty"`
+	// ZeroThreshold is the width of the native histogram's zero bucket.
+	ZeroThreshold float64 `yaml:"zero_threshold,omitempty"`
+	// Buckets are the classic histogram bucket upper bounds. Ignored when
+	// Native is true.
+	Buckets []float64 `yaml:"buckets,omitempty"`
+}
+
+// ValueConverterType maps a JSONPath to a set of string->string value
+// replacements, used to turn enum-like string values (e.g. "ok"/"fail")
+// into numeric ones.
+type ValueConverterType map[string]map[string]string
+
+// Metric describes a single metric family to extract from the scraped
+// JSON document.
+type Metric struct {
+	Name           string             `yaml:"name"`
+	Path           string             `yaml:"path"`
+	Labels         map[string]string  `yaml:"labels,omitempty"`
+	Type           ScrapeType         `yaml:"type,omitempty"`
+	Help           string             `yaml:"help"`
+	Values         map[string]string  `yaml:"values"`
+	EpochTimestamp string             `yaml:"epoch_timestamp,omitempty"`
+	ValueConverter ValueConverterType `yaml:"value_converter,omitempty"`
+	Histogram      *HistogramConfig   `yaml:"histogram,omitempty"`
+}
+
+// LoadConfig reads and parses the configuration file at path.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("error reading config file: %s", err)
+	}
+
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return config, fmt.Errorf("error parsing config file: %s", err)
+	}
+
+	for name, module := range config.Modules {
+		if err := module.HTTPClientConfig.Validate(); err != nil {
+			return config, fmt.Errorf("error validating http_client_config for module %q: %s", name, err)
+		}
+
+		if module.Type == "" {
+			module.Type = ModuleTypeJSON
+		}
+		if module.Type == ModuleTypePrometheusAPI && (module.PrometheusAPI == nil || len(module.PrometheusAPI.Endpoints) == 0) {
+			return config, fmt.Errorf("module %q is type prometheus_api but has no prometheus_api.endpoints configured", name)
+		}
+		if module.PrometheusAPI != nil && len(module.PrometheusAPI.AlertLabels) == 0 {
+			module.PrometheusAPI.AlertLabels = []string{"alertname", "severity"}
+		}
+
+		if module.Format == "" {
+			module.Format = FormatJSON
+		}
+		switch module.Format {
+		case FormatJSON, FormatPrometheus, FormatXML, FormatYAML, FormatAuto:
+		default:
+			return config, fmt.Errorf("module %q has unknown format %q", name, module.Format)
+		}
+
+		config.Modules[name] = module
+	}
+
+	for name, proxy := range config.Proxies {
+		switch proxy.Type {
+		case ProxyTargetExec:
+			if proxy.Command == "" {
+				return config, fmt.Errorf("proxy target %q is type exec but has no command configured", name)
+			}
+		case ProxyTargetHTTPForward:
+			if proxy.URL == "" {
+				return config, fmt.Errorf("proxy target %q is type http_forward but has no url configured", name)
+			}
+		default:
+			return config, fmt.Errorf("proxy target %q has unknown type %q", name, proxy.Type)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"container/list"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	cacheHitsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "json_exporter_cache_hits_total",
+		Help: "Number of /probe requests served from the upstream fetch cache.",
+	}, []string{"module"})
+	cacheMissesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "json_exporter_cache_misses_total",
+		Help: "Number of /probe requests that required a fresh upstream fetch.",
+	}, []string{"module"})
+	upstreamInflight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "json_exporter_upstream_inflight",
+		Help: "Number of upstream fetches currently in flight.",
+	}, []string{"module"})
+	upstreamDedupSavedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "json_exporter_upstream_dedup_saved_total",
+		Help: "Number of upstream fetches avoided by coalescing concurrent requests for the same key.",
+	}, []string{"module"})
+)
+
+func init() {
+	prometheus.MustRegister(cacheHitsTotal, cacheMissesTotal, upstreamInflight, upstreamDedupSavedTotal)
+}
+
+type cacheEntry struct {
+	key         string
+	data        []byte
+	contentType string
+	err         error
+	expiresAt   time.Time
+}
+
+// FetchCache is an in-memory, TTL-bounded LRU cache that also coalesces
+// concurrent fetches for the same key via singleflight, so that many
+// simultaneous /probe requests for one (module, target) pair result in a
+// single upstream HTTP call.
+type FetchCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	group      singleflight.Group
+}
+
+// NewFetchCache creates a FetchCache that holds at most maxEntries entries.
+// A maxEntries of zero means unbounded.
+func NewFetchCache(maxEntries int) *FetchCache {
+	return &FetchCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get returns the cached value for key if present and unexpired, otherwise
+// it calls fetch (coalescing concurrent calls for the same key) and caches
+// the result for ttl. It also returns the response's Content-Type, so that
+// FormatAuto sniffing works the same whether the response came from the
+// cache or a fresh fetch.
+func (c *FetchCache) Get(moduleName, key string, ttl time.Duration, fetch func() ([]byte, string, error)) ([]byte, string, error) {
+	if ttl > 0 {
+		c.mu.Lock()
+		if el, ok := c.entries[key]; ok {
+			entry := el.Value.(*cacheEntry)
+			if time.Now().Before(entry.expiresAt) {
+				c.order.MoveToFront(el)
+				c.mu.Unlock()
+				cacheHitsTotal.WithLabelValues(moduleName).Inc()
+				return entry.data, entry.contentType, entry.err
+			}
+			c.removeElement(el)
+		}
+		c.mu.Unlock()
+		cacheMissesTotal.WithLabelValues(moduleName).Inc()
+	}
+
+	upstreamInflight.WithLabelValues(moduleName).Inc()
+	defer upstreamInflight.WithLabelValues(moduleName).Dec()
+
+	type fetchResult struct {
+		data        []byte
+		contentType string
+	}
+
+	// A zero TTL disables storing the result, but concurrent callers for the
+	// same key still share a single upstream fetch via singleflight.
+	// singleflight reports shared=true to every caller of a coalesced call,
+	// including the one that ran fetch, so track execution separately.
+	executed := false
+	v, err, shared := c.group.Do(key, func() (interface{}, error) {
+		executed = true
+		data, contentType, fetchErr := fetch()
+		return fetchResult{data: data, contentType: contentType}, fetchErr
+	})
+	if shared && !executed {
+		upstreamDedupSavedTotal.WithLabelValues(moduleName).Inc()
+	}
+
+	result, _ := v.(fetchResult)
+
+	// Only the goroutine that actually executed the fetch stores the
+	// result; sharers that merely received the deduped result must not
+	// each push their own list element, or the cache accumulates orphaned
+	// entries that inflate order.Len() and can evict the live key early.
+	if ttl > 0 && executed {
+		c.mu.Lock()
+		el := c.order.PushFront(&cacheEntry{key: key, data: result.data, contentType: result.contentType, err: err, expiresAt: time.Now().Add(ttl)})
+		c.entries[key] = el
+		for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+			c.removeElement(c.order.Back())
+		}
+		c.mu.Unlock()
+	}
+
+	return result.data, result.contentType, err
+}
+
+func (c *FetchCache) removeElement(el *list.Element) {
+	if el == nil {
+		return
+	}
+	entry := el.Value.(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.order.Remove(el)
+}
+
+// CacheKey builds a cache key for a /probe request out of the configured
+// key fields ("module", "target", or a query parameter name). It defaults
+// to ["module", "target"] when fields is empty.
+func CacheKey(module, target string, fields []string, query url.Values) string {
+	if len(fields) == 0 {
+		fields = []string{"module", "target"}
+	}
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "module":
+			parts = append(parts, "module="+module)
+		case "target":
+			parts = append(parts, "target="+target)
+		default:
+			parts = append(parts, field+"="+query.Get(field))
+		}
+	}
+	return strings.Join(parts, "&")
+}
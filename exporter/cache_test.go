@@ -0,0 +1,155 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchCacheReusesResultWithinTTL(t *testing.T) {
+	c := NewFetchCache(0)
+
+	var calls int32
+	fetch := func() ([]byte, string, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("data"), "application/json", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		data, contentType, err := c.Get("m", "k", time.Minute, fetch)
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		if string(data) != "data" || contentType != "application/json" {
+			t.Fatalf("Get returned (%q, %q), want (%q, %q)", data, contentType, "data", "application/json")
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fetch called %d times, want 1 (result should have been served from cache)", got)
+	}
+}
+
+func TestFetchCacheZeroTTLStillDedupesInFlight(t *testing.T) {
+	c := NewFetchCache(0)
+
+	var calls int32
+	release := make(chan struct{})
+	fetch := func() ([]byte, string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []byte("data"), "", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := c.Get("m", "k", 0, fetch); err != nil {
+				t.Errorf("Get returned error: %v", err)
+			}
+		}()
+	}
+
+	// Give the goroutines a chance to all reach the in-flight fetch before
+	// releasing it, so they coalesce onto a single singleflight call.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fetch called %d times, want 1 (concurrent zero-TTL calls should still be deduped)", got)
+	}
+}
+
+func TestFetchCacheConcurrentMissStoresOneEntry(t *testing.T) {
+	c := NewFetchCache(1)
+
+	release := make(chan struct{})
+	fetch := func() ([]byte, string, error) {
+		<-release
+		return []byte("data"), "", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := c.Get("m", "k", time.Minute, fetch); err != nil {
+				t.Errorf("Get returned error: %v", err)
+			}
+		}()
+	}
+
+	// Give the goroutines a chance to all reach the in-flight fetch before
+	// releasing it, so they coalesce onto a single singleflight call.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	c.mu.Lock()
+	gotLen := c.order.Len()
+	c.mu.Unlock()
+	if gotLen != 1 {
+		t.Errorf("order.Len() = %d, want 1 (only the executing goroutine should store an entry)", gotLen)
+	}
+}
+
+func TestFetchCacheZeroTTLDoesNotCacheAcrossCalls(t *testing.T) {
+	c := NewFetchCache(0)
+
+	var calls int32
+	fetch := func() ([]byte, string, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("data"), "", nil
+	}
+
+	if _, _, err := c.Get("m", "k", 0, fetch); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if _, _, err := c.Get("m", "k", 0, fetch); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("fetch called %d times, want 2 (a zero TTL must not cache across separate calls)", got)
+	}
+}
+
+func TestFetchCacheExpiresAfterTTL(t *testing.T) {
+	c := NewFetchCache(0)
+
+	var calls int32
+	fetch := func() ([]byte, string, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("data"), "", nil
+	}
+
+	if _, _, err := c.Get("m", "k", time.Millisecond, fetch); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, _, err := c.Get("m", "k", time.Millisecond, fetch); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("fetch called %d times, want 2 (entry should have expired)", got)
+	}
+}
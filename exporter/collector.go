@@ -17,20 +17,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 
-	"github.com/go-kit/log"
-	"github.com/go-kit/log/level"
 	"github.com/prometheus-community/json_exporter/config"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/promlog"
 	"k8s.io/client-go/util/jsonpath"
 )
 
 type JSONMetricCollector struct {
 	JSONMetrics []JSONMetric
 	Data        []byte
-	Logger      log.Logger
+	Logger      *slog.Logger
 }
 
 type JSONMetric struct {
@@ -42,6 +40,7 @@ type JSONMetric struct {
 	ValueType              prometheus.ValueType
 	ValueConverter         config.ValueConverterType
 	EpochTimestampJSONPath string
+	HistogramConfig        *config.HistogramConfig
 }
 
 var jsonExporterStatusDesc *prometheus.Desc = prometheus.NewDesc("json_exporter_status", "Up/Down Status of JSON Exporter. Should always be 0.", nil, nil)
@@ -55,6 +54,11 @@ func (mc JSONMetricCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (mc JSONMetricCollector) Collect(ch chan<- prometheus.Metric) {
+	logger := mc.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	var rootData []byte = mc.Data
 
 	ch <- prometheus.MustNewConstMetric(
@@ -66,10 +70,10 @@ func (mc JSONMetricCollector) Collect(ch chan<- prometheus.Metric) {
 	for _, m := range mc.JSONMetrics {
 		switch m.Type {
 		case config.ValueScrape:
-			level.Info(mc.Logger).Log("msg", "Extracting value via ValueScrape for: "+m.KeyJSONPath)
-			value, err := extractValue(mc.Logger, mc.Data, m.KeyJSONPath, false)
+			logger.Info("Extracting value via ValueScrape", "path", m.KeyJSONPath)
+			value, err := extractValue(logger, mc.Data, m.KeyJSONPath, false)
 			if err != nil {
-				level.Error(mc.Logger).Log("msg", "Failed to extract value for metric", "path", m.KeyJSONPath, "err", err, "metric", m.Desc)
+				logger.Error("Failed to extract value for metric", "path", m.KeyJSONPath, "err", err, "metric", m.Desc)
 				continue
 			}
 
@@ -78,47 +82,45 @@ func (mc JSONMetricCollector) Collect(ch chan<- prometheus.Metric) {
 					m.Desc,
 					m.ValueType,
 					floatValue,
-					extractLabels(mc.Logger, mc.Data, m.LabelsJSONPaths)...,
+					extractLabels(logger, mc.Data, m.LabelsJSONPaths)...,
 				)
 			} else {
-				level.Error(mc.Logger).Log("msg", "Failed to convert extracted value to float64", "path", m.KeyJSONPath, "value", value, "err", err, "metric", m.Desc)
+				logger.Error("Failed to convert extracted value to float64", "path", m.KeyJSONPath, "value", value, "err", err, "metric", m.Desc)
 				continue
 			}
 
 		case config.ObjectScrape:
-			level.Info(mc.Logger).Log("msg", "Extracting value via Object for: "+m.KeyJSONPath)
+			logger.Info("Extracting value via Object", "path", m.KeyJSONPath)
 
-			values, err := extractValue(mc.Logger, mc.Data, m.KeyJSONPath, true)
+			values, err := extractValue(logger, mc.Data, m.KeyJSONPath, true)
 
 			if err != nil {
-				level.Error(mc.Logger).Log("msg", "Failed to extract json objects for metric", "err", err, "metric", m.Desc)
+				logger.Error("Failed to extract json objects for metric", "err", err, "metric", m.Desc)
 				continue
 			}
 
-			level.Debug(mc.Logger).Log("msg", "mc.Data: "+string(mc.Data))
-			level.Debug(mc.Logger).Log("msg", "extracted values: "+string(values))
-
-			level.Info(mc.Logger).Log("msg", "Extracted value for "+m.KeyJSONPath+". Going to loop through array if present")
+			logger.Debug("Extracted data", "data", string(mc.Data), "values", string(values))
+			logger.Info("Extracted value, going to loop through array if present", "path", m.KeyJSONPath)
 
 			var jsonData []interface{}
 			if err := json.Unmarshal([]byte(values), &jsonData); err == nil {
 				for _, data := range jsonData {
 					jdata, err := json.Marshal(data)
 					if err != nil {
-						level.Error(mc.Logger).Log("msg", "Failed to marshal data to json", "path", m.ValueJSONPath, "err", err, "metric", m.Desc, "data", data)
+						logger.Error("Failed to marshal data to json", "path", m.ValueJSONPath, "err", err, "metric", m.Desc, "data", data)
 						continue
 					}
-					level.Info(mc.Logger).Log("msg", "Extracting value for JSON element in array using thie ValueJSONPath of "+m.ValueJSONPath)
-					level.Debug(mc.Logger).Log("msg", "jdata: "+string(jdata))
-					value, err := extractValue(mc.Logger, jdata, m.ValueJSONPath, false)
+					logger.Info("Extracting value for JSON element in array", "path", m.ValueJSONPath)
+					logger.Debug("jdata", "jdata", string(jdata))
+					value, err := extractValue(logger, jdata, m.ValueJSONPath, false)
 
 					if err != nil {
-						level.Error(mc.Logger).Log("msg", "Failed to extract value for metric", "path", m.ValueJSONPath, "err", err, "metric", m.Desc)
+						logger.Error("Failed to extract value for metric", "path", m.ValueJSONPath, "err", err, "metric", m.Desc)
 						continue
 					}
 
 					value = convertValueIfNeeded(m, value)
-					level.Debug(mc.Logger).Log("msg", "Value for "+m.KeyJSONPath+" is "+value)
+					logger.Debug("Extracted value", "path", m.KeyJSONPath, "value", value)
 
 					// Choose what jdata to insert into extraction of label
 					if floatValue, err := SanitizeValue(value); err == nil {
@@ -126,29 +128,36 @@ func (mc JSONMetricCollector) Collect(ch chan<- prometheus.Metric) {
 							m.Desc,
 							m.ValueType,
 							floatValue,
-							extractLabelsWithParentNode(mc.Logger, jdata, rootData, m.LabelsJSONPaths)...,
+							extractLabelsWithParentNode(logger, jdata, rootData, m.LabelsJSONPaths)...,
 						)
 					} else {
-						level.Error(mc.Logger).Log("msg", "Failed to convert extracted value to float64", "path", m.ValueJSONPath, "value", value, "err", err, "metric", m.Desc)
+						logger.Error("Failed to convert extracted value to float64", "path", m.ValueJSONPath, "value", value, "err", err, "metric", m.Desc)
 						continue
 					}
 				}
 			} else {
-				level.Error(mc.Logger).Log("msg", "Failed to convert extracted objects to json", "err", err, "metric", m.Desc)
+				logger.Error("Failed to convert extracted objects to json", "err", err, "metric", m.Desc)
 				continue
 			}
+		case config.HistogramScrape:
+			logger.Info("Extracting value via HistogramScrape", "path", m.KeyJSONPath)
+
+			metric, err := buildHistogramMetric(logger, mc.Data, m)
+			if err != nil {
+				logger.Error("Failed to build histogram metric", "path", m.KeyJSONPath, "err", err, "metric", m.Desc)
+				continue
+			}
+			ch <- metric
+
 		default:
-			level.Error(mc.Logger).Log("msg", "Unknown scrape config type", "type", m.Type, "metric", m.Desc)
+			logger.Error("Unknown scrape config type", "type", m.Type, "metric", m.Desc)
 			continue
 		}
 	}
 }
 
-var promlogConfig *promlog.Config = &promlog.Config{}
-var collectorLogger log.Logger = promlog.New(promlogConfig)
-
 // Returns the last matching value at the given json path
-func extractValue(logger log.Logger, data []byte, path string, enableJSONOutput bool) (string, error) {
+func extractValue(logger *slog.Logger, data []byte, path string, enableJSONOutput bool) (string, error) {
 	var jsonData interface{}
 	buf := new(bytes.Buffer)
 
@@ -158,23 +167,23 @@ func extractValue(logger log.Logger, data []byte, path string, enableJSONOutput
 	}
 
 	if err := json.Unmarshal(data, &jsonData); err != nil {
-		level.Error(logger).Log("msg", "Failed to unmarshal data to json", "err", err, "data", data)
+		logger.Error("Failed to unmarshal data to json", "err", err, "data", data)
 		return "", err
 	}
 
-	level.Debug(logger).Log("msg", "jsonData: "+fmt.Sprintf("%v", jsonData))
+	logger.Debug("jsonData", "jsonData", fmt.Sprintf("%v", jsonData))
 
 	if err := j.Parse(path); err != nil {
-		level.Error(logger).Log("msg", "Failed to parse jsonpath", "err", err, "path", path, "data", data)
+		logger.Error("Failed to parse jsonpath", "err", err, "path", path, "data", data)
 		return "", err
 	}
 
 	if err := j.Execute(buf, jsonData); err != nil {
-		level.Error(logger).Log("msg", "Failed to execute jsonpath", "err", err, "path", path, "data", data)
+		logger.Error("Failed to execute jsonpath", "err", err, "path", path, "data", data)
 		return "", err
 	}
 
-	level.Debug(logger).Log("msg", "buf.string(): "+buf.String())
+	logger.Debug("buf.String()", "buf", buf.String())
 
 	// Since we are finally going to extract only float64, unquote if necessary
 	if res, err := jsonpath.UnquoteExtend(buf.String()); err == nil {
@@ -185,26 +194,26 @@ func extractValue(logger log.Logger, data []byte, path string, enableJSONOutput
 }
 
 // Returns the list of labels created from the list of provided json paths
-func extractLabels(logger log.Logger, data []byte, paths []string) []string {
+func extractLabels(logger *slog.Logger, data []byte, paths []string) []string {
 	labels := make([]string, len(paths))
 	for i, path := range paths {
 		if result, err := extractValue(logger, data, path, false); err == nil {
 			labels[i] = result
 		} else {
-			level.Error(logger).Log("msg", "Failed to extract label value", "err", err, "path", path, "data", data)
+			logger.Error("Failed to extract label value", "err", err, "path", path, "data", data)
 		}
 	}
 	return labels
 }
 
-func extractLabelsWithParentNode(logger log.Logger, childData []byte, rootData []byte, paths []string) []string {
+func extractLabelsWithParentNode(logger *slog.Logger, childData []byte, rootData []byte, paths []string) []string {
 	labels := make([]string, len(paths))
 	for i, path := range paths {
-		var selectedData []byte = selectRightJsonData(rootData, childData, path)
+		var selectedData []byte = selectRightJsonData(logger, rootData, childData, path)
 		if result, err := extractValue(logger, selectedData, path, false); err == nil {
 			labels[i] = result
 		} else {
-			level.Error(logger).Log("msg", "Failed to extract label value", "err", err, "path", path, "data", childData)
+			logger.Error("Failed to extract label value", "err", err, "path", path, "data", childData)
 		}
 	}
 	return labels
@@ -224,14 +233,14 @@ func convertValueIfNeeded(m JSONMetric, value string) string {
 	return value
 }
 
-func selectRightJsonData(rootData []byte, childData []byte, path string) []byte {
+func selectRightJsonData(logger *slog.Logger, rootData []byte, childData []byte, path string) []byte {
 	var noSpacePath string = strings.ReplaceAll(path, " ", "")
 
 	if strings.Contains(noSpacePath[0:4], "$") {
-		level.Debug(collectorLogger).Log("msg", "Using JSON data from the root")
+		logger.Debug("Using JSON data from the root")
 		return rootData
 	} else {
-		level.Debug(collectorLogger).Log("msg", "Using JSON data from the child nodes")
+		logger.Debug("Using JSON data from the child nodes")
 		return childData
 	}
 }
@@ -0,0 +1,116 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus-community/json_exporter/config"
+	config_util "github.com/prometheus/common/config"
+)
+
+// JSONFetcher fetches the raw JSON document for a single probe, applying
+// the method/headers/body/http_client_config configured on the module
+// (falling back to the exporter-wide request defaults).
+type JSONFetcher struct {
+	ctx    context.Context
+	logger *slog.Logger
+	module config.Module
+	query  url.Values
+}
+
+// NewJSONFetcher creates a JSONFetcher for the given module and the query
+// parameters of the incoming /probe request.
+func NewJSONFetcher(ctx context.Context, logger *slog.Logger, module config.Module, query url.Values) *JSONFetcher {
+	return &JSONFetcher{
+		ctx:    ctx,
+		logger: logger,
+		module: module,
+		query:  query,
+	}
+}
+
+// FetchJSON retrieves the raw response body from target, along with its
+// Content-Type header (used by FormatAuto to sniff the response format).
+func (f *JSONFetcher) FetchJSON(target string) ([]byte, string, error) {
+	client, err := config_util.NewClientFromConfig(f.module.HTTPClientConfig, "json_exporter")
+	if err != nil {
+		return nil, "", fmt.Errorf("error building HTTP client: %w", err)
+	}
+
+	method := f.module.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body *bytes.Reader
+	if f.module.Body != nil {
+		content := f.module.Body.Content
+		if f.module.Body.Templatize {
+			content = templatize(content, f.query)
+		}
+		body = bytes.NewReader([]byte(content))
+	} else {
+		body = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(f.ctx, method, target, body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request for target %q: %w", target, err)
+	}
+
+	for key, value := range f.module.Headers {
+		req.Header.Set(key, value)
+	}
+
+	f.logger.Debug("Fetching target", "target", target, "method", method)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, contentType, fmt.Errorf("failed to read response body from target %q: %w", target, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return data, contentType, fmt.Errorf("target %q returned HTTP status %s", target, resp.Status)
+	}
+
+	return data, contentType, nil
+}
+
+// templatize replaces `{{query_<name>}}` placeholders in content with the
+// corresponding query parameter from the incoming /probe request.
+func templatize(content string, query url.Values) string {
+	for name, values := range query {
+		if len(values) == 0 {
+			continue
+		}
+		content = strings.ReplaceAll(content, "{{query_"+name+"}}", values[0])
+	}
+	return content
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/clbanning/mxj/v2"
+	"github.com/prometheus-community/json_exporter/config"
+	"sigs.k8s.io/yaml"
+)
+
+// DetectFormatFromContentType maps an upstream response's Content-Type
+// header to a ResponseFormat, for modules configured with FormatAuto.
+// It falls back to FormatJSON when contentType is empty or unrecognized.
+func DetectFormatFromContentType(contentType string) config.ResponseFormat {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return config.FormatJSON
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "application/openmetrics-text"),
+		strings.Contains(contentType, "version=0.0.4"):
+		return config.FormatPrometheus
+	case mediaType == "application/xml", mediaType == "text/xml":
+		return config.FormatXML
+	case mediaType == "application/yaml", mediaType == "text/yaml", mediaType == "application/x-yaml":
+		return config.FormatYAML
+	default:
+		return config.FormatJSON
+	}
+}
+
+// DecodeToJSON converts raw response bytes fetched in the given format into
+// JSON, so that the existing JSONPath-based metric rules keep working
+// unmodified. FormatPrometheus is not handled here: it bypasses JSONPath
+// extraction entirely and is handled by PrometheusTextCollector instead.
+func DecodeToJSON(format config.ResponseFormat, data []byte) ([]byte, error) {
+	switch format {
+	case "", config.FormatJSON:
+		return data, nil
+	case config.FormatXML:
+		mv, err := mxj.NewMapXml(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xml response: %w", err)
+		}
+		return mv.Json()
+	case config.FormatYAML:
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert yaml response to json: %w", err)
+		}
+		return jsonData, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
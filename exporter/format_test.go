@@ -0,0 +1,114 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus-community/json_exporter/config"
+)
+
+func TestDecodeToJSON(t *testing.T) {
+	cases := []struct {
+		name    string
+		format  config.ResponseFormat
+		data    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "empty format defaults to json passthrough",
+			format: "",
+			data:   `{"a": 1}`,
+			want:   map[string]interface{}{"a": 1.0},
+		},
+		{
+			name:   "explicit json passthrough",
+			format: config.FormatJSON,
+			data:   `{"a": 1}`,
+			want:   map[string]interface{}{"a": 1.0},
+		},
+		{
+			name:   "xml",
+			format: config.FormatXML,
+			data:   `<root><a>1</a></root>`,
+			want:   map[string]interface{}{"root": map[string]interface{}{"a": "1"}},
+		},
+		{
+			name:   "yaml",
+			format: config.FormatYAML,
+			data:   "a: 1\n",
+			want:   map[string]interface{}{"a": 1.0},
+		},
+		{
+			name:    "unsupported format",
+			format:  "unknown",
+			data:    `{}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeToJSON(tc.format, []byte(tc.data))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("DecodeToJSON returned no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeToJSON returned error: %v", err)
+			}
+
+			var gotParsed map[string]interface{}
+			if err := json.Unmarshal(got, &gotParsed); err != nil {
+				t.Fatalf("failed to unmarshal result %q: %v", got, err)
+			}
+
+			for k, want := range tc.want {
+				if !reflect.DeepEqual(gotParsed[k], want) {
+					t.Errorf("field %q = %v, want %v", k, gotParsed[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectFormatFromContentType(t *testing.T) {
+	cases := []struct {
+		contentType string
+		want        config.ResponseFormat
+	}{
+		{"application/json", config.FormatJSON},
+		{"application/json; charset=utf-8", config.FormatJSON},
+		{"application/xml", config.FormatXML},
+		{"text/xml; charset=utf-8", config.FormatXML},
+		{"application/yaml", config.FormatYAML},
+		{"text/yaml", config.FormatYAML},
+		{"text/plain; version=0.0.4", config.FormatPrometheus},
+		{"", config.FormatJSON},
+		{"not a valid content type;;;", config.FormatJSON},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			if got := DetectFormatFromContentType(tc.contentType); got != tc.want {
+				t.Errorf("DetectFormatFromContentType(%q) = %q, want %q", tc.contentType, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus-community/json_exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// aggregatedHistogram is the shape of a pre-aggregated histogram object as
+// commonly exposed by application status endpoints.
+type aggregatedHistogram struct {
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum"`
+	Buckets map[string]uint64 `json:"buckets"`
+}
+
+// buildHistogramMetric extracts a HistogramScrape metric's observations and
+// returns either a classic bucketed histogram or a native (sparse) one,
+// depending on the metric's histogram configuration.
+func buildHistogramMetric(logger *slog.Logger, data []byte, m JSONMetric) (prometheus.Metric, error) {
+	hc := m.HistogramConfig
+	if hc == nil {
+		return nil, fmt.Errorf("metric %q is missing histogram configuration", m.Desc)
+	}
+
+	labels := extractLabels(logger, data, m.LabelsJSONPaths)
+
+	var observations []float64
+	var agg *aggregatedHistogram
+
+	switch {
+	case hc.ValuesPath != "":
+		raw, err := extractValue(logger, data, hc.ValuesPath, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &observations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal observed values at %q: %w", hc.ValuesPath, err)
+		}
+	case hc.AggregatedPath != "":
+		raw, err := extractValue(logger, data, hc.AggregatedPath, true)
+		if err != nil {
+			return nil, err
+		}
+		agg = &aggregatedHistogram{}
+		if err := json.Unmarshal([]byte(raw), agg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal aggregated histogram at %q: %w", hc.AggregatedPath, err)
+		}
+	default:
+		return nil, fmt.Errorf("metric %q has neither values_path nor aggregated_path set", m.Desc)
+	}
+
+	if hc.Native {
+		return buildNativeHistogram(m.Desc, hc, observations, agg, labels)
+	}
+	return buildClassicHistogram(m.Desc, hc, observations, agg, labels)
+}
+
+func buildClassicHistogram(desc *prometheus.Desc, hc *config.HistogramConfig, observations []float64, agg *aggregatedHistogram, labels []string) (prometheus.Metric, error) {
+	if agg != nil {
+		buckets := make(map[float64]uint64, len(agg.Buckets))
+		for le, count := range agg.Buckets {
+			bound, err := strconv.ParseFloat(le, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bucket bound %q: %w", le, err)
+			}
+			buckets[bound] = count
+		}
+		return prometheus.NewConstHistogram(desc, agg.Count, agg.Sum, buckets, labels...)
+	}
+
+	if len(hc.Buckets) == 0 {
+		return nil, fmt.Errorf("classic histogram %q has no buckets configured", desc)
+	}
+
+	buckets := make(map[float64]uint64, len(hc.Buckets))
+	for _, bound := range hc.Buckets {
+		buckets[bound] = 0
+	}
+
+	var count uint64
+	var sum float64
+	for _, v := range observations {
+		count++
+		sum += v
+		for _, bound := range hc.Buckets {
+			if v <= bound {
+				buckets[bound]++
+			}
+		}
+	}
+
+	return prometheus.NewConstHistogram(desc, count, sum, buckets, labels...)
+}
+
+func buildNativeHistogram(desc *prometheus.Desc, hc *config.HistogramConfig, observations []float64, agg *aggregatedHistogram, labels []string) (prometheus.Metric, error) {
+	schema := config.DefaultNativeHistogramSchema
+	if hc.Schema != nil {
+		schema = *hc.Schema
+	}
+
+	var count uint64
+	var sum float64
+	var zeroCount uint64
+	positiveBuckets := map[int]int64{}
+	negativeBuckets := map[int]int64{}
+
+	if agg != nil {
+		count = agg.Count
+		sum = agg.Sum
+
+		bounds := make([]float64, 0, len(agg.Buckets))
+		cumulative := make(map[float64]uint64, len(agg.Buckets))
+		for le, c := range agg.Buckets {
+			bound, err := strconv.ParseFloat(le, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bucket bound %q: %w", le, err)
+			}
+			// +Inf (and any other non-finite bound) has no exponential
+			// bucket of its own; the mass it carries over the highest
+			// finite bound is folded in as overflow below.
+			if math.IsInf(bound, 0) || math.IsNaN(bound) {
+				continue
+			}
+			bounds = append(bounds, bound)
+			cumulative[bound] = c
+		}
+		sort.Float64s(bounds)
+
+		// agg.Buckets holds cumulative "observations <= le" counts, like a
+		// classic histogram, but native exponential buckets need the count
+		// of observations that fall within each individual bucket, so we
+		// de-cumulate by walking the bounds in ascending order.
+		var prev uint64
+		var lastIdx int
+		var lastZero, lastNegative, haveLast bool
+		for _, bound := range bounds {
+			c := cumulative[bound]
+			delta := c - prev
+			prev = c
+			if math.Abs(bound) <= hc.ZeroThreshold {
+				zeroCount += delta
+				lastZero, haveLast = true, true
+				continue
+			}
+			idx := nativeBucketIndex(math.Abs(bound), schema)
+			if bound < 0 {
+				negativeBuckets[idx] += int64(delta)
+			} else {
+				positiveBuckets[idx] += int64(delta)
+			}
+			lastIdx, lastNegative, lastZero, haveLast = idx, bound < 0, false, true
+		}
+
+		// agg.Count may exceed the cumulative count at the highest finite
+		// bound (an implicit +Inf/overflow bucket); fold that remainder
+		// into the highest bucket rather than dropping it, since
+		// NewConstNativeHistogram requires the bucket counts to sum to
+		// count.
+		if remainder := count - prev; count > prev && haveLast {
+			switch {
+			case lastZero:
+				zeroCount += remainder
+			case lastNegative:
+				negativeBuckets[lastIdx] += int64(remainder)
+			default:
+				positiveBuckets[lastIdx] += int64(remainder)
+			}
+		}
+	} else {
+		for _, v := range observations {
+			count++
+			sum += v
+			if math.Abs(v) <= hc.ZeroThreshold {
+				zeroCount++
+				continue
+			}
+			idx := nativeBucketIndex(math.Abs(v), schema)
+			if v < 0 {
+				negativeBuckets[idx]++
+			} else {
+				positiveBuckets[idx]++
+			}
+		}
+	}
+
+	return prometheus.NewConstNativeHistogram(desc, count, sum, positiveBuckets, negativeBuckets, zeroCount, schema, hc.ZeroThreshold, time.Time{}, labels...)
+}
+
+// nativeBucketIndex returns the index of the exponential bucket
+// (base^(k-1), base^k] that v falls into, for base = 2^(2^-schema).
+func nativeBucketIndex(v float64, schema int32) int {
+	base := math.Pow(2, math.Pow(2, -float64(schema)))
+	return int(math.Ceil(math.Log(v) / math.Log(base)))
+}
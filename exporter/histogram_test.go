@@ -0,0 +1,171 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus-community/json_exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestNativeBucketIndex(t *testing.T) {
+	cases := []struct {
+		name   string
+		v      float64
+		schema int32
+		want   int
+	}{
+		{"schema0 base of bucket 1", 1, 0, 0},
+		{"schema0 base of bucket 2", 2, 0, 1},
+		{"schema0 base of bucket 4", 4, 0, 2},
+		{"schema3 just above 1", 1.01, 3, 1},
+		{"schema3 exactly 1", 1, 3, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := nativeBucketIndex(tc.v, tc.schema)
+			if got != tc.want {
+				t.Errorf("nativeBucketIndex(%v, %d) = %d, want %d", tc.v, tc.schema, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildClassicHistogramFromObservations(t *testing.T) {
+	hc := &config.HistogramConfig{Buckets: []float64{1, 5, 10}}
+	observations := []float64{0.5, 2, 2, 7, 20}
+
+	metric, err := buildClassicHistogram(prometheus.NewDesc("h", "help", nil, nil), hc, observations, nil, nil)
+	if err != nil {
+		t.Fatalf("buildClassicHistogram returned error: %v", err)
+	}
+
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		t.Fatalf("failed to write metric: %v", err)
+	}
+
+	h := m.GetHistogram()
+	if got, want := h.GetSampleCount(), uint64(5); got != want {
+		t.Errorf("sample count = %d, want %d", got, want)
+	}
+	if got, want := h.GetSampleSum(), 31.5; got != want {
+		t.Errorf("sample sum = %v, want %v", got, want)
+	}
+
+	wantCounts := map[float64]uint64{1: 1, 5: 3, 10: 4}
+	for _, b := range h.GetBucket() {
+		if want, ok := wantCounts[b.GetUpperBound()]; ok && b.GetCumulativeCount() != want {
+			t.Errorf("bucket %v cumulative count = %d, want %d", b.GetUpperBound(), b.GetCumulativeCount(), want)
+		}
+	}
+}
+
+func TestBuildNativeHistogramFromObservations(t *testing.T) {
+	hc := &config.HistogramConfig{Native: true, ZeroThreshold: 0.001}
+	observations := []float64{1, 1, 2, 4, -1}
+
+	metric, err := buildNativeHistogram(prometheus.NewDesc("h", "help", nil, nil), hc, observations, nil, nil)
+	if err != nil {
+		t.Fatalf("buildNativeHistogram returned error: %v", err)
+	}
+
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		t.Fatalf("failed to write metric: %v", err)
+	}
+
+	h := m.GetHistogram()
+	if got, want := h.GetSampleCount(), uint64(5); got != want {
+		t.Errorf("sample count = %d, want %d", got, want)
+	}
+	if got, want := h.GetSampleSum(), 7.0; got != want {
+		t.Errorf("sample sum = %v, want %v", got, want)
+	}
+	if got, want := h.GetSchema(), config.DefaultNativeHistogramSchema; got != want {
+		t.Errorf("schema = %d, want %d", got, want)
+	}
+	if len(h.GetPositiveSpan()) == 0 {
+		t.Error("expected at least one positive span for the positive observations")
+	}
+	if len(h.GetNegativeSpan()) == 0 {
+		t.Error("expected at least one negative span for the -1 observation")
+	}
+}
+
+// TestBuildNativeHistogramFromAggregatedDeCumulates guards against treating
+// the cumulative "observations <= le" counts in an aggregated histogram as
+// per-bucket deltas: le=1 and le=2 fall below zeroThreshold and must
+// de-cumulate into a zero bucket count of 5 (not 2+5=7), leaving a single
+// bucket delta of 10-5=5 for le=4 (not the raw cumulative count of 10).
+func TestBuildNativeHistogramFromAggregatedDeCumulates(t *testing.T) {
+	hc := &config.HistogramConfig{Native: true, ZeroThreshold: 3}
+	agg := &aggregatedHistogram{
+		Count:   10,
+		Sum:     42,
+		Buckets: map[string]uint64{"1": 2, "2": 5, "4": 10},
+	}
+
+	metric, err := buildNativeHistogram(prometheus.NewDesc("h", "help", nil, nil), hc, nil, agg, nil)
+	if err != nil {
+		t.Fatalf("buildNativeHistogram returned error: %v", err)
+	}
+
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		t.Fatalf("failed to write metric: %v", err)
+	}
+
+	h := m.GetHistogram()
+	if got, want := h.GetZeroCount(), uint64(5); got != want {
+		t.Errorf("zero bucket count = %d, want %d", got, want)
+	}
+
+	deltas := h.GetPositiveDelta()
+	if len(deltas) != 1 {
+		t.Fatalf("expected a single populated positive bucket, got %d", len(deltas))
+	}
+	if got, want := deltas[0], int64(5); got != want {
+		t.Errorf("positive bucket delta = %d, want %d (cumulative le counts must be de-cumulated)", got, want)
+	}
+}
+
+func TestBuildClassicHistogramFromAggregated(t *testing.T) {
+	hc := &config.HistogramConfig{AggregatedPath: "$.histogram"}
+	agg := &aggregatedHistogram{
+		Count:   10,
+		Sum:     42,
+		Buckets: map[string]uint64{"1": 2, "5": 8, "10": 10},
+	}
+
+	metric, err := buildClassicHistogram(prometheus.NewDesc("h", "help", nil, nil), hc, nil, agg, nil)
+	if err != nil {
+		t.Fatalf("buildClassicHistogram returned error: %v", err)
+	}
+
+	var m dto.Metric
+	if err := metric.Write(&m); err != nil {
+		t.Fatalf("failed to write metric: %v", err)
+	}
+
+	h := m.GetHistogram()
+	if got, want := h.GetSampleCount(), uint64(10); got != want {
+		t.Errorf("sample count = %d, want %d", got, want)
+	}
+	if got, want := h.GetSampleSum(), 42.0; got != want {
+		t.Errorf("sample sum = %v, want %v", got, want)
+	}
+}
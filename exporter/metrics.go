@@ -0,0 +1,91 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/prometheus-community/json_exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CreateMetricsList turns a module's metric configuration into the
+// JSONMetrics consumed by JSONMetricCollector.
+func CreateMetricsList(c config.Module) ([]JSONMetric, error) {
+	var metrics []JSONMetric
+	for _, metric := range c.Metrics {
+		var labelNames []string
+		var labelJSONPaths []string
+		for name, path := range metric.Labels {
+			labelNames = append(labelNames, name)
+			labelJSONPaths = append(labelJSONPaths, path)
+		}
+
+		switch metric.Type {
+		case config.ValueScrape:
+			valueType, err := parseValueType(metric.Values)
+			if err != nil {
+				return nil, err
+			}
+			for name, path := range metric.Values {
+				metrics = append(metrics, JSONMetric{
+					Desc:            prometheus.NewDesc(name, metric.Help, labelNames, nil),
+					Type:            config.ValueScrape,
+					KeyJSONPath:     path,
+					LabelsJSONPaths: labelJSONPaths,
+					ValueType:       valueType,
+					ValueConverter:  metric.ValueConverter,
+				})
+			}
+		case config.ObjectScrape:
+			for _, path := range metric.Values {
+				metrics = append(metrics, JSONMetric{
+					Desc:                   prometheus.NewDesc(metric.Name, metric.Help, labelNames, nil),
+					Type:                   config.ObjectScrape,
+					KeyJSONPath:            metric.Path,
+					ValueJSONPath:          path,
+					LabelsJSONPaths:        labelJSONPaths,
+					ValueType:              prometheus.GaugeValue,
+					ValueConverter:         metric.ValueConverter,
+					EpochTimestampJSONPath: metric.EpochTimestamp,
+				})
+			}
+		case config.HistogramScrape:
+			if metric.Histogram == nil {
+				return nil, fmt.Errorf("metric %q is type histogram but has no histogram configuration", metric.Name)
+			}
+			keyPath := metric.Histogram.ValuesPath
+			if keyPath == "" {
+				keyPath = metric.Histogram.AggregatedPath
+			}
+			metrics = append(metrics, JSONMetric{
+				Desc:            prometheus.NewDesc(metric.Name, metric.Help, labelNames, nil),
+				Type:            config.HistogramScrape,
+				KeyJSONPath:     keyPath,
+				LabelsJSONPaths: labelJSONPaths,
+				HistogramConfig: metric.Histogram,
+			})
+		default:
+			return nil, fmt.Errorf("unknown metric type %q for metric %q", metric.Type, metric.Name)
+		}
+	}
+	return metrics, nil
+}
+
+func parseValueType(values map[string]string) (prometheus.ValueType, error) {
+	if len(values) == 0 {
+		return prometheus.GaugeValue, fmt.Errorf("no values configured")
+	}
+	return prometheus.GaugeValue, nil
+}
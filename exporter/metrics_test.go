@@ -0,0 +1,88 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus-community/json_exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestCreateMetricsListLabelPairing guards against labelNames and
+// labelJSONPaths being built from independent map iterations over
+// metric.Labels, which Go does not guarantee to agree on order and can pair
+// a label name with the wrong JSONPath.
+func TestCreateMetricsListLabelPairing(t *testing.T) {
+	data := []byte(`{"region": "us-east-1", "environment": "prod", "az": "1a", "cluster": "blue", "value": 42}`)
+
+	module := config.Module{
+		Metrics: []config.Metric{
+			{
+				Name: "some_metric",
+				Type: config.ValueScrape,
+				Help: "help",
+				Labels: map[string]string{
+					"region":      "$.region",
+					"environment": "$.environment",
+					"az":          "$.az",
+					"cluster":     "$.cluster",
+				},
+				Values: map[string]string{"some_metric": "$.value"},
+			},
+		},
+	}
+
+	// Run repeatedly: map iteration order varies run to run, so a single
+	// pass could pass by chance even with the bug present.
+	for i := 0; i < 50; i++ {
+		metrics, err := CreateMetricsList(module)
+		if err != nil {
+			t.Fatalf("CreateMetricsList returned error: %v", err)
+		}
+
+		registry := prometheus.NewPedanticRegistry()
+		if err := registry.Register(JSONMetricCollector{JSONMetrics: metrics, Data: data}); err != nil {
+			t.Fatalf("failed to register collector: %v", err)
+		}
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("failed to gather metrics: %v", err)
+		}
+
+		want := map[string]string{
+			"region":      "us-east-1",
+			"environment": "prod",
+			"az":          "1a",
+			"cluster":     "blue",
+		}
+
+		found := false
+		for _, mf := range families {
+			if mf.GetName() != "some_metric" {
+				continue
+			}
+			found = true
+			for _, l := range mf.GetMetric()[0].GetLabel() {
+				if got, want := l.GetValue(), want[l.GetName()]; got != want {
+					t.Errorf("label %q = %q, want %q", l.GetName(), got, want)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("some_metric not found in gathered metrics")
+		}
+	}
+}
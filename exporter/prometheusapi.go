@@ -0,0 +1,189 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/prometheus-community/json_exporter/config"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+var (
+	prometheusRuleGroupEvaluationSecondsDesc = prometheus.NewDesc(
+		"prometheus_rule_group_evaluation_seconds",
+		"Duration in seconds of the last evaluation of a rule group on the target Prometheus.",
+		[]string{"name", "file"}, nil,
+	)
+	prometheusTargetHealthDesc = prometheus.NewDesc(
+		"prometheus_target_health",
+		"Health of a scrape target on the target Prometheus (1 = up, 0 = not up).",
+		[]string{"job", "instance"}, nil,
+	)
+)
+
+// PrometheusAPICollector queries a target Prometheus' HTTP API and re-exposes
+// the requested endpoints (alerts, rules, targets) as metrics.
+type PrometheusAPICollector struct {
+	Target  string
+	Config  config.PrometheusAPIConfig
+	Context context.Context
+	Logger  *slog.Logger
+
+	alertDesc *prometheus.Desc
+}
+
+// NewPrometheusAPICollector builds a PrometheusAPICollector for target,
+// precomputing the prometheus_alert Desc from cfg.AlertLabels so that
+// Describe and Collect agree on its variable labels.
+func NewPrometheusAPICollector(target string, cfg config.PrometheusAPIConfig, ctx context.Context, logger *slog.Logger) PrometheusAPICollector {
+	return PrometheusAPICollector{
+		Target:  target,
+		Config:  cfg,
+		Context: ctx,
+		Logger:  logger,
+		alertDesc: prometheus.NewDesc(
+			"prometheus_alert",
+			"Number of alerts (firing or pending, per the state label) reported by the target Prometheus with this label set.",
+			append(append([]string{}, alertLabels(cfg)...), "state"), nil,
+		),
+	}
+}
+
+// alertLabels returns the alert label set to propagate, applying the
+// ["alertname", "severity"] default when unconfigured.
+func alertLabels(cfg config.PrometheusAPIConfig) []string {
+	if len(cfg.AlertLabels) == 0 {
+		return []string{"alertname", "severity"}
+	}
+	return cfg.AlertLabels
+}
+
+func (c PrometheusAPICollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jsonExporterStatusDesc
+	ch <- c.alertDesc
+	ch <- prometheusRuleGroupEvaluationSecondsDesc
+	ch <- prometheusTargetHealthDesc
+}
+
+func (c PrometheusAPICollector) Collect(ch chan<- prometheus.Metric) {
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client, err := api.NewClient(api.Config{Address: c.Target})
+	if err != nil {
+		logger.Error("Failed to create Prometheus API client", "target", c.Target, "err", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(jsonExporterStatusDesc, prometheus.GaugeValue, 0)
+
+	v1api := v1.NewAPI(client)
+
+	for _, endpoint := range c.Config.Endpoints {
+		switch endpoint {
+		case "alerts":
+			c.collectAlerts(ctx, v1api, logger, ch)
+		case "rules":
+			c.collectRules(ctx, v1api, logger, ch)
+		case "targets":
+			c.collectTargets(ctx, v1api, logger, ch)
+		default:
+			logger.Error("Unknown prometheus_api endpoint", "endpoint", endpoint)
+		}
+	}
+}
+
+func (c PrometheusAPICollector) collectAlerts(ctx context.Context, v1api v1.API, logger *slog.Logger, ch chan<- prometheus.Metric) {
+	result, err := v1api.Alerts(ctx)
+	if err != nil {
+		logger.Error("Failed to fetch alerts", "target", c.Target, "err", err)
+		return
+	}
+
+	// Two alerts can map to the same label set once only the configured
+	// alert_labels (plus state) are kept, e.g. when a distinguishing label
+	// like "instance" isn't included. Emitting one const metric per alert
+	// would then produce duplicate label sets, which fails Gather on a
+	// PedanticRegistry and 500s the whole scrape, so count alerts per
+	// label set instead.
+	names := alertLabels(c.Config)
+	counts := make(map[string]float64)
+	labelValues := make(map[string][]string)
+	for _, a := range result.Alerts {
+		values := make([]string, 0, len(names)+1)
+		for _, name := range names {
+			values = append(values, string(a.Labels[model.LabelName(name)]))
+		}
+		values = append(values, string(a.State))
+
+		key := strings.Join(values, "\xff")
+		counts[key]++
+		labelValues[key] = values
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.alertDesc, prometheus.GaugeValue, count, labelValues[key]...)
+	}
+}
+
+func (c PrometheusAPICollector) collectRules(ctx context.Context, v1api v1.API, logger *slog.Logger, ch chan<- prometheus.Metric) {
+	result, err := v1api.Rules(ctx)
+	if err != nil {
+		logger.Error("Failed to fetch rules", "target", c.Target, "err", err)
+		return
+	}
+
+	for _, group := range result.Groups {
+		ch <- prometheus.MustNewConstMetric(
+			prometheusRuleGroupEvaluationSecondsDesc,
+			prometheus.GaugeValue,
+			group.EvaluationTime,
+			group.Name, group.File,
+		)
+	}
+}
+
+func (c PrometheusAPICollector) collectTargets(ctx context.Context, v1api v1.API, logger *slog.Logger, ch chan<- prometheus.Metric) {
+	result, err := v1api.Targets(ctx)
+	if err != nil {
+		logger.Error("Failed to fetch targets", "target", c.Target, "err", err)
+		return
+	}
+
+	for _, target := range result.Active {
+		health := 0.0
+		if target.Health == v1.HealthGood {
+			health = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			prometheusTargetHealthDesc,
+			prometheus.GaugeValue,
+			health,
+			string(target.Labels["job"]), string(target.Labels["instance"]),
+		)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"log/slog"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// PrometheusTextCollector re-parses a target's Prometheus text-exposition
+// response and re-emits it, optionally under a module-configured prefix,
+// turning json_exporter into a light proxy/relabeler for format: prometheus
+// modules.
+type PrometheusTextCollector struct {
+	Prefix string
+	Data   []byte
+	Logger *slog.Logger
+}
+
+// NewPrometheusTextCollector creates a PrometheusTextCollector for the given
+// raw Prometheus text-exposition response.
+func NewPrometheusTextCollector(prefix string, data []byte, logger *slog.Logger) PrometheusTextCollector {
+	return PrometheusTextCollector{Prefix: prefix, Data: data, Logger: logger}
+}
+
+// Describe intentionally sends nothing: the metric families aren't known
+// until the response is parsed in Collect, so this collector is registered
+// as "unchecked".
+func (c PrometheusTextCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c PrometheusTextCollector) Collect(ch chan<- prometheus.Metric) {
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(c.Data))
+	if err != nil {
+		logger.Error("Failed to parse prometheus text response", "err", err)
+		return
+	}
+
+	for name, family := range families {
+		desc := prometheus.NewDesc(c.Prefix+name, family.GetHelp(), labelNamesOf(family.GetMetric()), nil)
+		for _, m := range family.GetMetric() {
+			value, valueType, ok := familyMetricValue(family.GetType(), m)
+			if !ok {
+				logger.Debug("Skipping unsupported metric type for relabeling", "metric", name, "type", family.GetType())
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(desc, valueType, value, labelValuesOf(m)...)
+		}
+	}
+}
+
+func labelNamesOf(metrics []*dto.Metric) []string {
+	if len(metrics) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(metrics[0].GetLabel()))
+	for _, l := range metrics[0].GetLabel() {
+		names = append(names, l.GetName())
+	}
+	return names
+}
+
+func labelValuesOf(m *dto.Metric) []string {
+	values := make([]string, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		values = append(values, l.GetValue())
+	}
+	return values
+}
+
+func familyMetricValue(t dto.MetricType, m *dto.Metric) (float64, prometheus.ValueType, bool) {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return m.GetCounter().GetValue(), prometheus.CounterValue, true
+	case dto.MetricType_GAUGE:
+		return m.GetGauge().GetValue(), prometheus.GaugeValue, true
+	case dto.MetricType_UNTYPED:
+		return m.GetUntyped().GetValue(), prometheus.UntypedValue, true
+	default:
+		// Histograms and summaries aren't re-emitted as-is; they would need
+		// to be decomposed into their own const metrics to roundtrip.
+		return 0, prometheus.UntypedValue, false
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+
+	"github.com/prometheus-community/json_exporter/config"
+)
+
+// FetchProxyTarget retrieves a downstream exporter's /metrics output for the
+// /proxy endpoint, either by executing a local command or by forwarding an
+// HTTP request, and returns the raw body along with its content type.
+func FetchProxyTarget(ctx context.Context, target config.ProxyTarget) ([]byte, string, error) {
+	switch target.Type {
+	case config.ProxyTargetExec:
+		return fetchExecProxyTarget(ctx, target)
+	case config.ProxyTargetHTTPForward:
+		return fetchHTTPForwardProxyTarget(ctx, target)
+	default:
+		return nil, "", fmt.Errorf("unknown proxy target type %q", target.Type)
+	}
+}
+
+func fetchExecProxyTarget(ctx context.Context, target config.ProxyTarget) ([]byte, string, error) {
+	cmd := exec.CommandContext(ctx, target.Command, target.Args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, max: target.MaxOutputBytes}
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("command %q failed: %w (stderr: %s)", target.Command, err, stderr.String())
+	}
+
+	return stdout.Bytes(), "text/plain; version=0.0.4", nil
+}
+
+func fetchHTTPForwardProxyTarget(ctx context.Context, target config.ProxyTarget) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request for proxy target %q: %w", target.URL, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	if target.MaxOutputBytes > 0 {
+		body = io.LimitReader(body, target.MaxOutputBytes)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response body from proxy target %q: %w", target.URL, err)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// limitedBuffer caps the number of bytes written to buf at max, returning an
+// error once exceeded instead of growing unbounded. A max of zero means
+// unbounded.
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	max int64
+}
+
+func (w *limitedBuffer) Write(p []byte) (int, error) {
+	if w.max <= 0 {
+		return w.buf.Write(p)
+	}
+
+	remaining := w.max - int64(w.buf.Len())
+	if remaining <= 0 {
+		return 0, fmt.Errorf("output exceeded max_output_bytes (%d)", w.max)
+	}
+	if int64(len(p)) > remaining {
+		n, _ := w.buf.Write(p[:remaining])
+		return n, fmt.Errorf("output exceeded max_output_bytes (%d)", w.max)
+	}
+	return w.buf.Write(p)
+}